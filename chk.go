@@ -1,12 +1,14 @@
 /*
 Extended DNS Check (chk) is a command-line tool that provides enhanced DNS lookup functionality.
-It checks PTR records for IP addresses and fetches additional IP information using the ipinfo.io API.
+It checks PTR records for IP addresses and fetches additional IP information from a pluggable provider (ipinfo.io, ip-api.com, RIPEstat, or a local MaxMind database).
 For domains or subdomains, it displays A and AAAA records and retrieves IP information for each resolved address.
 
 Features:
-  - Lookup A and AAAA records for domains and subdomains
+  - Lookup A, AAAA, MX, NS, TXT, SOA, CAA, SRV, DNSKEY and DS records for domains and subdomains
   - Retrieve PTR records for IP addresses
-  - Fetch detailed IP information (city, region, country, etc.) using ipinfo.io API
+  - Fetch detailed IP information (city, region, country, etc.) using a pluggable provider
+  - Validate answers against the DNSSEC chain of trust
+  - Discover .local hosts and services over multicast DNS
   - Support for IPv4 and IPv6 addresses
   - Colorized output for better readability
   - Parallel processing using goroutines
@@ -23,13 +25,17 @@ License: MIT License (https://kofany.mit-license.org)
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
-	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"sync"
 	"syscall"
@@ -37,30 +43,313 @@ import (
 
 	"github.com/alecthomas/kong"
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/miekg/dns"
+
+	"github.com/kofany/chk/internal/cache"
+	"github.com/kofany/chk/internal/chkerr"
+	"github.com/kofany/chk/internal/dnssec"
+	"github.com/kofany/chk/internal/ipinfo"
+	"github.com/kofany/chk/internal/mdns"
+	"github.com/kofany/chk/internal/resolver"
 )
 
+// cliDescription documents the process exit codes chk reports, so
+// scripts calling chk from CI or a monitoring pipeline can branch on
+// them without parsing error text. Shown by --help.
+const cliDescription = `Extended DNS Check: DNS/PTR/IP-info lookups with pluggable resolvers and providers.
+
+Exit codes:
+  0  success
+  1  general error
+  2  name does not exist (NXDOMAIN)
+  3  operation timed out
+  4  network error
+  5  rate limited
+  6  API authentication failed`
+
 var CLI struct {
-	IPv4    bool          `help:"Show only IPv4 (A) records" short:"4"`
-	IPv6    bool          `help:"Show only IPv6 (AAAA) records" short:"6"`
-	Timeout time.Duration `help:"Timeout for HTTP requests" default:"5s"`
-	Target  string        `arg name:"domain/ip" help:"Domain, subdomain or IP to check"`
+	IPv4        bool          `help:"Show only IPv4 (A) records" short:"4"`
+	IPv6        bool          `help:"Show only IPv6 (AAAA) records" short:"6"`
+	Timeout     time.Duration `help:"Timeout for HTTP requests" default:"5s"`
+	DNSServer   string        `help:"Nameserver to query: host:port for udp/tcp/dot, a URL for doh, an sdns:// stamp for dnscrypt. Defaults to the system resolver." name:"dns-server"`
+	DNSProtocol string        `help:"DNS transport to use" name:"dns-protocol" default:"udp" enum:"udp,tcp,dot,doh,doq,dnscrypt"`
+	DNSTimeout  time.Duration `help:"Timeout for DNS queries" name:"dns-timeout" default:"5s"`
+	Input       string        `help:"Read targets (one per line) from file, or stdin when set to '-'" name:"input"`
+	Concurrency int           `help:"Maximum number of targets looked up concurrently" name:"concurrency" default:"10"`
+	Output      string        `help:"Write results to file instead of stdout" name:"output"`
+	Format      string        `help:"Output format for results" name:"format" default:"text" enum:"text,json,ndjson,csv"`
+	Provider    string        `help:"IP-info provider to query" name:"provider" default:"ipinfo" enum:"ipinfo,ipapi,ripestat,maxmind"`
+	GeoIPDB     string        `help:"Path to a GeoLite2-City .mmdb file (maxmind provider)" name:"geoip-db"`
+	ASNDB       string        `help:"Path to a GeoLite2-ASN .mmdb file (maxmind provider)" name:"asn-db"`
+	APIToken    string        `help:"API token for the ipinfo provider" name:"api-token"`
+	Type        string        `help:"Comma-separated record types to look up" name:"type" default:"A,AAAA"`
+	DNSSEC      bool          `help:"Validate answers against the DNSSEC chain of trust" name:"dnssec"`
+	NoCache     bool          `help:"Disable the PTR/IP-info cache" name:"no-cache"`
+	ClearCache  bool          `help:"Clear the persisted PTR/IP-info cache and exit" name:"clear-cache"`
+	IPInfoTTL   time.Duration `help:"How long to cache IP-info results" name:"ipinfo-cache-ttl" default:"24h"`
+	MDNS        bool          `help:"Use multicast DNS for .local targets instead of unicast resolution" name:"mdns"`
+	MDNSTimeout time.Duration `help:"How long to listen for mDNS responses" name:"mdns-timeout" default:"1s"`
+	Target      string        `arg:"" optional:"" name:"domain/ip" help:"Domain, subdomain or IP to check; omit when using --input"`
+}
+
+// supportedTypes are the record types accepted by --type, in the order
+// they should be looked up.
+var supportedTypes = []string{"A", "AAAA", "MX", "NS", "TXT", "SOA", "CAA", "SRV", "DNSKEY", "DS"}
+
+// parseTypes validates and converts a comma-separated --type value into
+// the corresponding wire-format query types.
+func parseTypes(s string) ([]uint16, error) {
+	var qtypes []uint16
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		qtype, ok := dns.StringToType[name]
+		if !ok || !contains(supportedTypes, name) {
+			return nil, fmt.Errorf("unsupported record type %q (supported: %s)", name, strings.Join(supportedTypes, ", "))
+		}
+		qtypes = append(qtypes, qtype)
+	}
+	if len(qtypes) == 0 {
+		return nil, fmt.Errorf("--type must list at least one record type")
+	}
+	return qtypes, nil
 }
 
-type IPInfo struct {
-	IP       string `json:"ip"`
-	Hostname string `json:"hostname"`
-	City     string `json:"city"`
-	Region   string `json:"region"`
-	Country  string `json:"country"`
-	Loc      string `json:"loc"`
-	Org      string `json:"org"`
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
+// dnsResolver is non-nil once --dns-server selects an explicit resolver
+// backend, or when --dnssec or a record type the net package cannot
+// look up needs a wire-format UDP resolver (the system nameserver);
+// when nil, lookups fall back to the system resolver via the net
+// package.
+var dnsResolver resolver.Resolver
+
+// resolveAddresses resolves name's address records for qtype (dns.TypeA
+// or dns.TypeAAAA), preferring the configured dnsResolver over
+// net.LookupIP when one is set. When --dnssec is enabled, it also
+// returns the validation status of the answer.
+func resolveAddresses(ctx context.Context, name string, qtype uint16) ([]net.IP, dnssec.Status, error) {
+	if dnsResolver == nil {
+		addrs, err := net.LookupIP(name)
+		if err != nil {
+			return nil, "", chkerr.FromDNSError(err)
+		}
+		var ips []net.IP
+		for _, ip := range addrs {
+			if (ip.To4() == nil) == (qtype == dns.TypeAAAA) {
+				ips = append(ips, ip)
+			}
+		}
+		return ips, "", nil
+	}
+
+	rrs, err := dnsResolver.Query(ctx, name, qtype)
+	if err != nil {
+		return nil, "", err
+	}
+	var ips []net.IP
+	for _, rr := range rrs {
+		switch rec := rr.(type) {
+		case *dns.A:
+			ips = append(ips, rec.A)
+		case *dns.AAAA:
+			ips = append(ips, rec.AAAA)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, "", chkerr.Wrap(chkerr.ErrNXDomain, fmt.Errorf("no %s records found for %s", dns.TypeToString[qtype], name))
+	}
+
+	var status dnssec.Status
+	if dnssecValidator != nil {
+		status = dnssecValidator.Validate(ctx, name, qtype, rrs)
+	}
+	return ips, status, nil
+}
+
+// legacyLookup serves an extended record type through the standard
+// library when no wire-format resolver is configured. Only the types
+// net exposes lookups for are supported.
+func legacyLookup(name string, qtype uint16) ([]dns.RR, error) {
+	fqdn := dns.Fqdn(name)
+	switch qtype {
+	case dns.TypeMX:
+		mxs, err := net.LookupMX(name)
+		if err != nil {
+			return nil, chkerr.FromDNSError(err)
+		}
+		rrs := make([]dns.RR, len(mxs))
+		for i, mx := range mxs {
+			rrs[i] = &dns.MX{
+				Hdr:        dns.RR_Header{Name: fqdn, Rrtype: dns.TypeMX, Class: dns.ClassINET},
+				Preference: mx.Pref,
+				Mx:         mx.Host,
+			}
+		}
+		return rrs, nil
+	case dns.TypeNS:
+		nss, err := net.LookupNS(name)
+		if err != nil {
+			return nil, chkerr.FromDNSError(err)
+		}
+		rrs := make([]dns.RR, len(nss))
+		for i, ns := range nss {
+			rrs[i] = &dns.NS{
+				Hdr: dns.RR_Header{Name: fqdn, Rrtype: dns.TypeNS, Class: dns.ClassINET},
+				Ns:  ns.Host,
+			}
+		}
+		return rrs, nil
+	case dns.TypeTXT:
+		txts, err := net.LookupTXT(name)
+		if err != nil {
+			return nil, chkerr.FromDNSError(err)
+		}
+		rrs := make([]dns.RR, len(txts))
+		for i, txt := range txts {
+			rrs[i] = &dns.TXT{
+				Hdr: dns.RR_Header{Name: fqdn, Rrtype: dns.TypeTXT, Class: dns.ClassINET},
+				Txt: []string{txt},
+			}
+		}
+		return rrs, nil
+	default:
+		return nil, fmt.Errorf("%s lookups require --dns-server (the system resolver does not support them)", dns.TypeToString[qtype])
+	}
+}
+
+// lookupRecord queries target for a single extended record type (any
+// supportedTypes entry other than A/AAAA) and validates it when
+// --dnssec is set.
+func lookupRecord(ctx context.Context, target string, qtype uint16) Result {
+	result := Result{Name: target, Type: dns.TypeToString[qtype]}
+
+	if dnsResolver == nil {
+		rrs, err := legacyLookup(target, qtype)
+		if err != nil {
+			result.Error = fmt.Errorf("error looking up %s records: %w", result.Type, err)
+			return result
+		}
+		result.RRs = rrs
+		return result
+	}
+
+	rrs, err := dnsResolver.Query(ctx, target, qtype)
+	if err != nil {
+		result.Error = fmt.Errorf("error looking up %s records: %w", result.Type, err)
+		return result
+	}
+	result.RRs = rrs
+	if dnssecValidator != nil {
+		result.DNSSEC = dnssecValidator.Validate(ctx, target, qtype, rrs)
+	}
+	return result
+}
+
+// defaultPTRCacheTTL is used to cache PTR answers that carry no TTL of
+// their own, i.e. those served by net.LookupAddr.
+const defaultPTRCacheTTL = time.Hour
+
+// lookupPTR resolves the reverse DNS name(s) for ip, preferring the
+// configured dnsResolver over net.LookupAddr when one is set, and
+// returns how long the answer may be cached.
+func lookupPTR(ctx context.Context, ip string) ([]string, time.Duration, error) {
+	if dnsResolver == nil {
+		names, err := net.LookupAddr(ip)
+		if err != nil {
+			return nil, 0, chkerr.FromDNSError(err)
+		}
+		return names, defaultPTRCacheTTL, nil
+	}
+
+	arpa, err := dns.ReverseAddr(ip)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error looking up PTR records: %v", err)
+	}
+	rrs, err := dnsResolver.Query(ctx, arpa, dns.TypePTR)
+	if err != nil {
+		return nil, 0, err
+	}
+	var names []string
+	ttl := defaultPTRCacheTTL
+	for _, rr := range rrs {
+		ptr, ok := rr.(*dns.PTR)
+		if !ok {
+			continue
+		}
+		names = append(names, ptr.Ptr)
+		if rrTTL := time.Duration(rr.Header().Ttl) * time.Second; len(names) == 1 || rrTTL < ttl {
+			ttl = rrTTL
+		}
+	}
+	return names, ttl, nil
+}
+
+// ptrCache and ipInfoCache hold recently seen PTR and IP-info answers so
+// that a batch run over an overlapping set of IPs doesn't repeat
+// lookups. Both are nil when --no-cache is set.
+var (
+	ptrCache    *cache.Cache
+	ipInfoCache *cache.Cache
+)
+
+// cachedLookupPTR wraps lookupPTR with ptrCache, honoring the answer's
+// own DNS TTL.
+func cachedLookupPTR(ctx context.Context, ip string) ([]string, error) {
+	if ptrCache != nil {
+		var names []string
+		if ptrCache.Get(ip, &names) {
+			return names, nil
+		}
+	}
+	names, ttl, err := lookupPTR(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	if ptrCache != nil {
+		ptrCache.Set(ip, names, ttl)
+	}
+	return names, nil
+}
+
+// cachedIPInfoLookup wraps ipInfoProvider.Lookup with ipInfoCache,
+// honoring --ipinfo-cache-ttl.
+func cachedIPInfoLookup(ctx context.Context, ip string) (*ipinfo.Info, error) {
+	if ipInfoCache != nil {
+		var info ipinfo.Info
+		if ipInfoCache.Get(ip, &info) {
+			return &info, nil
+		}
+	}
+	info, err := ipInfoProvider.Lookup(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	if ipInfoCache != nil {
+		ipInfoCache.Set(ip, info, CLI.IPInfoTTL)
+	}
+	return info, nil
+}
+
+// Result is the outcome of looking up one record type for one target.
+// A/AAAA results carry a resolved IP plus its reverse DNS and IP-info
+// enrichment; other record types carry their raw RRset instead.
 type Result struct {
+	Name   string
+	Type   string
 	IP     string
+	RRs    []dns.RR
 	PTR    []string
-	IPInfo *IPInfo
+	IPInfo *ipinfo.Info
 	IsIPv6 bool
+	DNSSEC dnssec.Status
 	Error  error
 }
 
@@ -72,55 +361,54 @@ var (
 	magenta = color.New(color.FgMagenta).SprintFunc()
 )
 
-var httpClient *http.Client
+// ipInfoProvider looks up IP-info for each resolved address, selected via
+// --provider.
+var ipInfoProvider ipinfo.Provider
 
-func getIPInfo(ctx context.Context, ip string) (*IPInfo, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://ipinfo.io/"+ip+"/json", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// lookupTypes are the record types requested via --type.
+var lookupTypes []uint16
 
-	var ipInfo IPInfo
-	if err := json.NewDecoder(resp.Body).Decode(&ipInfo); err != nil {
-		return nil, err
-	}
-	return &ipInfo, nil
-}
+// dnssecValidator is non-nil once --dnssec selects validation; answers
+// are then checked against the chain of trust as they are looked up.
+var dnssecValidator *dnssec.Validator
 
-func lookupIP(ctx context.Context, ip string, isIPv6 bool, resultChan chan<- Result, wg *sync.WaitGroup) {
+func lookupIP(ctx context.Context, target, ip string, isIPv6 bool, status dnssec.Status, resultChan chan<- Result, wg *sync.WaitGroup) {
 	defer wg.Done()
-	result := Result{IP: ip, IsIPv6: isIPv6}
+	recordType := "A"
+	if isIPv6 {
+		recordType = "AAAA"
+	}
+	result := Result{Name: target, Type: recordType, IP: ip, IsIPv6: isIPv6, DNSSEC: status}
 
 	var wgInternal sync.WaitGroup
 	wgInternal.Add(2)
 
 	go func() {
 		defer wgInternal.Done()
-		names, err := net.LookupAddr(ip)
-		if err != nil {
-			result.Error = fmt.Errorf("error looking up PTR records: %v", err)
-		} else {
+		names, err := cachedLookupPTR(ctx, ip)
+		switch {
+		case err == nil:
 			result.PTR = names
+		case errors.Is(err, chkerr.ErrNXDomain):
+			// No PTR record for ip: a common, unremarkable outcome,
+			// not worth surfacing as an error.
+		default:
+			result.Error = fmt.Errorf("error looking up PTR records: %w", err)
 		}
 	}()
 
 	go func() {
 		defer wgInternal.Done()
-		ipInfo, err := getIPInfo(ctx, ip)
+		info, err := cachedIPInfoLookup(ctx, ip)
 		if err != nil {
+			wrapped := fmt.Errorf("error fetching IP info: %w", err)
 			if result.Error != nil {
-				result.Error = fmt.Errorf("%v; error fetching IP info: %v", result.Error, err)
+				result.Error = fmt.Errorf("%w; %w", result.Error, wrapped)
 			} else {
-				result.Error = fmt.Errorf("error fetching IP info: %v", err)
+				result.Error = wrapped
 			}
 		} else {
-			result.IPInfo = ipInfo
+			result.IPInfo = info
 		}
 	}()
 
@@ -128,51 +416,548 @@ func lookupIP(ctx context.Context, ip string, isIPv6 bool, resultChan chan<- Res
 	resultChan <- result
 }
 
-func printResult(result Result) {
-	recordType := "A"
-	if result.IsIPv6 {
-		recordType = "AAAA"
+// dnssecText colors a DNSSEC status for terminal output: green for
+// Secure, yellow for the provably unsigned Insecure, red for Bogus and
+// Indeterminate alike, since both mean the answer cannot be trusted.
+func dnssecText(status dnssec.Status) string {
+	switch status {
+	case dnssec.Secure:
+		return green(string(status))
+	case dnssec.Insecure:
+		return yellow(string(status))
+	default:
+		return red(string(status))
 	}
-	fmt.Printf("%s: %s\n", cyan(fmt.Sprintf("%s Record", recordType)), yellow(result.IP))
+}
 
-	if len(result.PTR) > 0 {
-		fmt.Printf("  %s: %s\n", cyan("PTR Records"), green(strings.Join(result.PTR, ", ")))
+func writeResultText(w io.Writer, result Result) {
+	switch result.Type {
+	case "A", "AAAA":
+		fmt.Fprintf(w, "%s: %s\n", cyan(fmt.Sprintf("%s Record", result.Type)), yellow(result.IP))
+
+		if len(result.PTR) > 0 {
+			fmt.Fprintf(w, "  %s: %s\n", cyan("PTR Records"), green(strings.Join(result.PTR, ", ")))
+		}
+
+		if result.IPInfo != nil {
+			fmt.Fprintf(w, "  %s: %s\n", cyan("City"), green(result.IPInfo.City))
+			fmt.Fprintf(w, "  %s: %s\n", cyan("Region"), green(result.IPInfo.Region))
+			fmt.Fprintf(w, "  %s: %s\n", cyan("Country"), green(result.IPInfo.Country))
+			fmt.Fprintf(w, "  %s: %s\n", cyan("Location"), green(result.IPInfo.Loc))
+			fmt.Fprintf(w, "  %s: %s\n", cyan("Organization"), green(result.IPInfo.Org))
+			fmt.Fprintf(w, "  %s: %s\n", cyan("ASN"), green(result.IPInfo.ASN))
+			fmt.Fprintf(w, "  %s: %s\n", cyan("AS Name"), green(result.IPInfo.ASName))
+			fmt.Fprintf(w, "  %s: %s\n", cyan("Network"), green(result.IPInfo.Network))
+		}
+	default:
+		fmt.Fprintf(w, "%s: %s\n", cyan(fmt.Sprintf("%s Record", result.Type)), yellow(result.Name))
+		for _, rr := range result.RRs {
+			fmt.Fprintf(w, "  %s\n", green(rr.String()))
+		}
 	}
 
-	if result.IPInfo != nil {
-		fmt.Printf("  %s: %s\n", cyan("City"), green(result.IPInfo.City))
-		fmt.Printf("  %s: %s\n", cyan("Region"), green(result.IPInfo.Region))
-		fmt.Printf("  %s: %s\n", cyan("Country"), green(result.IPInfo.Country))
-		fmt.Printf("  %s: %s\n", cyan("Location"), green(result.IPInfo.Loc))
-		fmt.Printf("  %s: %s\n", cyan("Organization"), green(result.IPInfo.Org))
+	if result.DNSSEC != "" {
+		fmt.Fprintf(w, "  %s: %s\n", cyan("DNSSEC"), dnssecText(result.DNSSEC))
 	}
 
 	if result.Error != nil {
-		fmt.Printf("  %s: %s\n", red("Error"), red(result.Error.Error()))
+		if errors.Is(result.Error, chkerr.ErrNXDomain) {
+			fmt.Fprintf(w, "  %s: %s\n", cyan("Not found"), result.Error.Error())
+		} else {
+			fmt.Fprintf(w, "  %s: %s\n", red("Error"), red(result.Error.Error()))
+		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
+}
+
+// resultRecord is the flat, serializable form of Result used by the
+// json, ndjson and csv output formats.
+type resultRecord struct {
+	Name    string `json:"name,omitempty"`
+	IP      string `json:"ip,omitempty"`
+	Type    string `json:"type"`
+	Records string `json:"records,omitempty"`
+	PTR     string `json:"ptr,omitempty"`
+	City    string `json:"city,omitempty"`
+	Region  string `json:"region,omitempty"`
+	Country string `json:"country,omitempty"`
+	Loc     string `json:"loc,omitempty"`
+	Org     string `json:"org,omitempty"`
+	ASN     string `json:"asn,omitempty"`
+	ASName  string `json:"as_name,omitempty"`
+	Network string `json:"network,omitempty"`
+	DNSSEC  string `json:"dnssec,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func newResultRecord(result Result) resultRecord {
+	rec := resultRecord{Name: result.Name, IP: result.IP, Type: result.Type}
+	if len(result.RRs) > 0 {
+		strs := make([]string, len(result.RRs))
+		for i, rr := range result.RRs {
+			strs[i] = rr.String()
+		}
+		rec.Records = strings.Join(strs, "; ")
+	}
+	if len(result.PTR) > 0 {
+		rec.PTR = strings.Join(result.PTR, "; ")
+	}
+	if result.IPInfo != nil {
+		rec.City = result.IPInfo.City
+		rec.Region = result.IPInfo.Region
+		rec.Country = result.IPInfo.Country
+		rec.Loc = result.IPInfo.Loc
+		rec.Org = result.IPInfo.Org
+		rec.ASN = result.IPInfo.ASN
+		rec.ASName = result.IPInfo.ASName
+		rec.Network = result.IPInfo.Network
+	}
+	if result.DNSSEC != "" {
+		rec.DNSSEC = string(result.DNSSEC)
+	}
+	if result.Error != nil {
+		rec.Error = result.Error.Error()
+	}
+	return rec
 }
 
-func validateInput(input string) error {
-	if net.ParseIP(input) != nil {
+// renderResults writes results to w in the requested format: "text"
+// (the default, colorized layout), "json" (a single indented array),
+// "ndjson" (one compact object per line), or "csv".
+func renderResults(results []Result, format string, w io.Writer) error {
+	switch format {
+	case "", "text":
+		for _, result := range results {
+			writeResultText(w, result)
+		}
+		return nil
+	case "json":
+		records := make([]resultRecord, len(results))
+		for i, result := range results {
+			records[i] = newResultRecord(result)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, result := range results {
+			if err := enc.Encode(newResultRecord(result)); err != nil {
+				return err
+			}
+		}
 		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		header := []string{"name", "ip", "type", "records", "ptr", "city", "region", "country", "loc", "org", "asn", "as_name", "network", "dnssec", "error"}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, result := range results {
+			rec := newResultRecord(result)
+			row := []string{rec.Name, rec.IP, rec.Type, rec.Records, rec.PTR, rec.City, rec.Region, rec.Country, rec.Loc, rec.Org, rec.ASN, rec.ASName, rec.Network, rec.DNSSEC, rec.Error}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// readTargets reads one target per line from path, or from stdin when
+// path is "-". Blank lines and lines starting with '#' are skipped.
+func readTargets(path string) ([]string, error) {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening input file: %v", err)
+		}
+		defer f.Close()
+		r = f
 	}
-	if _, err := net.LookupHost(input); err != nil {
-		return fmt.Errorf("invalid domain or IP address: %v", err)
+
+	var targets []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
 	}
-	return nil
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading input: %v", err)
+	}
+	return targets, nil
 }
 
+// resolveTargets builds the list of targets to process: the lines read
+// from --input (or stdin, when --input is "-"), or the single
+// positional Target argument.
+func resolveTargets() ([]string, error) {
+	if CLI.Input != "" {
+		return readTargets(CLI.Input)
+	}
+	if CLI.Target == "" {
+		return nil, fmt.Errorf("a domain/ip argument or --input is required")
+	}
+	return []string{CLI.Target}, nil
+}
+
+// wantsAddressFamily reports whether isIPv6 passes the --ipv4/--ipv6
+// filters (both unset means no filtering).
+func wantsAddressFamily(isIPv6 bool) bool {
+	return (CLI.IPv4 && !isIPv6) || (CLI.IPv6 && isIPv6) || (!CLI.IPv4 && !CLI.IPv6)
+}
+
+// lookupAddresses resolves target's address records for qtype (dns.TypeA
+// or dns.TypeAAAA) and looks up PTR/IP-info for each address matching
+// the --ipv4/--ipv6 filters, returning one Result per address.
+func lookupAddresses(ctx context.Context, target string, qtype uint16) []Result {
+	isIPv6 := qtype == dns.TypeAAAA
+	if !wantsAddressFamily(isIPv6) {
+		return nil
+	}
+
+	ips, status, err := resolveAddresses(ctx, target, qtype)
+	if err != nil {
+		recordType := "A"
+		if isIPv6 {
+			recordType = "AAAA"
+		}
+		return []Result{{Name: target, Type: recordType, Error: fmt.Errorf("error looking up IP for domain: %w", err)}}
+	}
+
+	var wg sync.WaitGroup
+	resultChan := make(chan Result, len(ips))
+	for _, ip := range ips {
+		wg.Add(1)
+		go lookupIP(ctx, target, ip.String(), isIPv6, status, resultChan, &wg)
+	}
+	wg.Wait()
+	close(resultChan)
+
+	var results []Result
+	for result := range resultChan {
+		results = append(results, result)
+	}
+	return results
+}
+
+// mdnsClient is non-nil once configured in main; used to discover
+// --mdns targets instead of querying dnsResolver/net.
+var mdnsClient *mdns.Client
+
+// mdnsQueryTypes are the record types a single mDNS discovery round
+// queries for, covering both a plain .local hostname (A/AAAA) and a
+// service type like _http._tcp.local (PTR, with SRV/TXT/address records
+// for each instance arriving as additional data on the same answer).
+var mdnsQueryTypes = []uint16{dns.TypePTR, dns.TypeSRV, dns.TypeTXT, dns.TypeA, dns.TypeAAAA}
+
+// isMDNSTarget reports whether target is a multicast-DNS name: a
+// .local hostname, or a service type such as _http._tcp.local.
+func isMDNSTarget(target string) bool {
+	name := strings.ToLower(strings.TrimSuffix(target, "."))
+	return strings.HasSuffix(name, ".local")
+}
+
+// lookupMDNS discovers target over multicast DNS, grouping whatever RRs
+// are received within --mdns-timeout into one Result per record type.
+// Every query type in mdnsQueryTypes fires concurrently so the whole
+// round takes one --mdns-timeout window, not one per type.
+func lookupMDNS(ctx context.Context, target string) []Result {
+	type queryResult struct {
+		rrs []dns.RR
+		err error
+	}
+	responses := make([]queryResult, len(mdnsQueryTypes))
+
+	var wg sync.WaitGroup
+	wg.Add(len(mdnsQueryTypes))
+	for i, qtype := range mdnsQueryTypes {
+		go func(i int, qtype uint16) {
+			defer wg.Done()
+			rrs, err := mdnsClient.Query(ctx, target, qtype)
+			responses[i] = queryResult{rrs: rrs, err: err}
+		}(i, qtype)
+	}
+	wg.Wait()
+
+	rrsByType := make(map[string][]dns.RR)
+	var order []string
+	for _, resp := range responses {
+		if resp.err != nil {
+			return []Result{{Name: target, Type: "mDNS", Error: fmt.Errorf("error performing mDNS discovery: %w", resp.err)}}
+		}
+		for _, rr := range resp.rrs {
+			t := dns.TypeToString[rr.Header().Rrtype]
+			if _, ok := rrsByType[t]; !ok {
+				order = append(order, t)
+			}
+			rrsByType[t] = append(rrsByType[t], rr)
+		}
+	}
+
+	if len(order) == 0 {
+		return []Result{{Name: target, Type: "mDNS", Error: fmt.Errorf("no mDNS responses received within %s", CLI.MDNSTimeout)}}
+	}
+
+	results := make([]Result, 0, len(order))
+	for _, t := range order {
+		results = append(results, Result{Name: target, Type: t, RRs: dedupeRRs(rrsByType[t])})
+	}
+	return results
+}
+
+// dedupeRRs drops repeated RRs. The five query types in mdnsQueryTypes
+// are fired independently, but a PTR response's Additional section
+// commonly carries the SRV/TXT/A/AAAA records of the discovered
+// instance too (RFC 6762), so the direct queries for those types return
+// the same records again.
+func dedupeRRs(rrs []dns.RR) []dns.RR {
+	seen := make(map[string]bool, len(rrs))
+	out := make([]dns.RR, 0, len(rrs))
+	for _, rr := range rrs {
+		key := rr.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, rr)
+	}
+	return out
+}
+
+// lookupTarget resolves target, parsing it as a literal IP when
+// possible, and otherwise looks up every record type in lookupTypes.
+func lookupTarget(ctx context.Context, target string) []Result {
+	if CLI.MDNS && isMDNSTarget(target) {
+		return lookupMDNS(ctx, target)
+	}
+
+	if ip := net.ParseIP(target); ip != nil {
+		isIPv6 := ip.To4() == nil
+		if !wantsAddressFamily(isIPv6) {
+			return nil
+		}
+		resultChan := make(chan Result, 1)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go lookupIP(ctx, target, target, isIPv6, "", resultChan, &wg)
+		wg.Wait()
+		close(resultChan)
+		return []Result{<-resultChan}
+	}
+
+	var results []Result
+	for _, qtype := range lookupTypes {
+		switch qtype {
+		case dns.TypeA, dns.TypeAAAA:
+			results = append(results, lookupAddresses(ctx, target, qtype)...)
+		default:
+			results = append(results, lookupRecord(ctx, target, qtype))
+		}
+	}
+	return results
+}
+
+// runBatch resolves every target, capping the number of targets
+// processed concurrently at concurrency via a worker pool. Progress is
+// reported to stderr as a per-target counter, suppressed when stderr
+// is not a terminal.
+func runBatch(ctx context.Context, targets []string, concurrency int) []Result {
+	jobs := make(chan string)
+	out := make(chan []Result)
+
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for target := range jobs {
+				select {
+				case out <- lookupTarget(ctx, target):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, target := range targets {
+			select {
+			case jobs <- target:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(out)
+	}()
+
+	showProgress := isatty.IsTerminal(os.Stderr.Fd())
+	var results []Result
+	processed := 0
+	for rs := range out {
+		results = append(results, rs...)
+		processed++
+		if showProgress {
+			fmt.Fprintf(os.Stderr, "\rChecking records... %d/%d completed", processed, len(targets))
+		}
+	}
+	if showProgress {
+		fmt.Fprint(os.Stderr, "\r"+strings.Repeat(" ", 60)+"\r")
+	}
+	return results
+}
+
+// exitCodePrecedence ranks exit codes from most to least severe, so
+// aggregateExitCode's output depends only on which categories of
+// failure occurred, not on the order batch workers happened to finish
+// in (runBatch drains results as they complete, not in target order).
+var exitCodePrecedence = []int{
+	chkerr.ExitAPIAuth,
+	chkerr.ExitRateLimited,
+	chkerr.ExitNetwork,
+	chkerr.ExitTimeout,
+	chkerr.ExitGeneral,
+	chkerr.ExitNXDomain,
+}
+
+// aggregateExitCode picks the process exit code chk reports for a batch
+// of results: the most severe category present per exitCodePrecedence,
+// chkerr.ExitNXDomain if every failure was a not-found, or 0 if there
+// were none.
+func aggregateExitCode(results []Result) int {
+	seen := make(map[int]bool)
+	for _, result := range results {
+		if result.Error == nil {
+			continue
+		}
+		seen[chkerr.ExitCode(result.Error)] = true
+	}
+	for _, code := range exitCodePrecedence {
+		if seen[code] {
+			return code
+		}
+	}
+	return chkerr.ExitOK
+}
+
+// cacheCapacity bounds how many entries each of the PTR and IP-info
+// caches may hold in memory.
+const cacheCapacity = 4096
+
 func main() {
-	ctx := kong.Parse(&CLI)
+	ctx := kong.Parse(&CLI, kong.Description(cliDescription))
+
+	if CLI.NoCache {
+		ptrCache, ipInfoCache = nil, nil
+	} else {
+		cacheDir, err := cache.DefaultDir()
+		if err != nil {
+			fmt.Printf("%s: %v\n", red("Error"), red(err))
+			ctx.Exit(1)
+		}
+		ptrCache = cache.New(cacheCapacity, filepath.Join(cacheDir, "ptr.json"))
+		ipInfoCache = cache.New(cacheCapacity, filepath.Join(cacheDir, "ipinfo.json"))
+
+		if CLI.ClearCache {
+			if err := ptrCache.Clear(); err != nil {
+				fmt.Printf("%s: %v\n", red("Error"), red(err))
+				ctx.Exit(1)
+			}
+			if err := ipInfoCache.Clear(); err != nil {
+				fmt.Printf("%s: %v\n", red("Error"), red(err))
+				ctx.Exit(1)
+			}
+			fmt.Println(green("Cache cleared"))
+			return
+		}
+
+		if err := ptrCache.Load(); err != nil {
+			fmt.Printf("%s: %v\n", yellow("Warning"), yellow(err))
+		}
+		if err := ipInfoCache.Load(); err != nil {
+			fmt.Printf("%s: %v\n", yellow("Warning"), yellow(err))
+		}
+	}
+
+	targets, err := resolveTargets()
+	if err != nil {
+		fmt.Printf("%s: %v\n", red("Error"), red(err))
+		ctx.Exit(1)
+	}
+
+	types, err := parseTypes(CLI.Type)
+	if err != nil {
+		fmt.Printf("%s: %v\n", red("Error"), red(err))
+		ctx.Exit(1)
+	}
+	lookupTypes = types
+
+	needsWireResolver := CLI.DNSSEC
+	for _, qtype := range types {
+		if qtype != dns.TypeA && qtype != dns.TypeAAAA && qtype != dns.TypeMX && qtype != dns.TypeNS && qtype != dns.TypeTXT {
+			needsWireResolver = true
+		}
+	}
+
+	if CLI.DNSServer != "" {
+		r, err := resolver.New(resolver.Config{
+			Server:   CLI.DNSServer,
+			Protocol: resolver.Protocol(CLI.DNSProtocol),
+			Timeout:  CLI.DNSTimeout,
+			DNSSEC:   CLI.DNSSEC,
+		})
+		if err != nil {
+			fmt.Printf("%s: %v\n", red("Error"), red(err))
+			ctx.Exit(1)
+		}
+		dnsResolver = r
+	} else if needsWireResolver {
+		r, err := resolver.New(resolver.Config{
+			Protocol: resolver.ProtocolUDP,
+			Timeout:  CLI.DNSTimeout,
+			DNSSEC:   CLI.DNSSEC,
+		})
+		if err != nil {
+			fmt.Printf("%s: %v\n", red("Error"), red(err))
+			ctx.Exit(1)
+		}
+		dnsResolver = r
+	}
+
+	if CLI.DNSSEC {
+		dnssecValidator = dnssec.NewValidator(dnsResolver)
+	}
 
-	if err := validateInput(CLI.Target); err != nil {
+	provider, err := ipinfo.New(ipinfo.Config{
+		Provider: ipinfo.Name(CLI.Provider),
+		APIToken: CLI.APIToken,
+		GeoIPDB:  CLI.GeoIPDB,
+		ASNDB:    CLI.ASNDB,
+		Timeout:  CLI.Timeout,
+	})
+	if err != nil {
 		fmt.Printf("%s: %v\n", red("Error"), red(err))
 		ctx.Exit(1)
 	}
+	ipInfoProvider = provider
 
-	httpClient = &http.Client{Timeout: CLI.Timeout}
+	mdnsClient = mdns.New(mdns.Config{Timeout: CLI.MDNSTimeout})
 
 	mainCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -186,66 +971,48 @@ func main() {
 		cancel()
 	}()
 
-	ip := net.ParseIP(CLI.Target)
+	concurrency := CLI.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
 
-	var wg sync.WaitGroup
-	resultChan := make(chan Result, 10) // Buffered channel
-	var results []Result
+	results := runBatch(mainCtx, targets, concurrency)
 
-	var ips []net.IP
-	if ip != nil {
-		ips = append(ips, ip)
-	} else {
-		var err error
-		ips, err = net.LookupIP(CLI.Target)
+	select {
+	case <-mainCtx.Done():
+		fmt.Println("\nOperation cancelled")
+		return
+	default:
+	}
+
+	dest := os.Stdout
+	if CLI.Output != "" {
+		f, err := os.Create(CLI.Output)
 		if err != nil {
-			fmt.Printf("%s: %v\n", red("Error looking up IP for domain"), red(err))
+			fmt.Printf("%s: %v\n", red("Error creating output file"), red(err))
 			ctx.Exit(1)
 		}
+		defer f.Close()
+		dest = f
 	}
 
-	totalIPs := 0
-	for _, ip := range ips {
-		isIPv6 := ip.To4() == nil
-		if (CLI.IPv4 && !isIPv6) || (CLI.IPv6 && isIPv6) || (!CLI.IPv4 && !CLI.IPv6) {
-			totalIPs++
-			wg.Add(1)
-			go lookupIP(mainCtx, ip.String(), isIPv6, resultChan, &wg)
-		}
+	if err := renderResults(results, CLI.Format, dest); err != nil {
+		fmt.Printf("%s: %v\n", red("Error rendering results"), red(err))
+		ctx.Exit(1)
 	}
 
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
-
-	done := make(chan bool)
-	go func() {
-		for result := range resultChan {
-			results = append(results, result)
+	if ptrCache != nil {
+		if err := ptrCache.Save(); err != nil {
+			fmt.Printf("%s: %v\n", yellow("Warning"), yellow(err))
 		}
-		close(done)
-	}()
-
-	fmt.Print(yellow("Checking records... Please wait"))
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
-
-	processed := 0
-	for {
-		select {
-		case <-mainCtx.Done():
-			fmt.Println("\nOperation cancelled")
-			return
-		case <-done:
-			fmt.Print("\r" + strings.Repeat(" ", 60) + "\r") // Clear the progress message
-			for _, result := range results {
-				printResult(result)
-			}
-			return
-		case <-ticker.C:
-			processed = len(results)
-			fmt.Printf("\rChecking records... %d/%d completed", processed, totalIPs)
+	}
+	if ipInfoCache != nil {
+		if err := ipInfoCache.Save(); err != nil {
+			fmt.Printf("%s: %v\n", yellow("Warning"), yellow(err))
 		}
 	}
+
+	if exitCode := aggregateExitCode(results); exitCode != 0 {
+		ctx.Exit(exitCode)
+	}
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kofany/chk/internal/chkerr"
+)
+
+func TestAggregateExitCode(t *testing.T) {
+	cause := errors.New("boom")
+	cases := []struct {
+		name    string
+		results []Result
+		want    int
+	}{
+		{"no results", nil, chkerr.ExitOK},
+		{"all success", []Result{{Name: "a"}, {Name: "b"}}, chkerr.ExitOK},
+		{
+			"all nxdomain",
+			[]Result{
+				{Name: "a", Error: chkerr.Wrap(chkerr.ErrNXDomain, cause)},
+				{Name: "b", Error: chkerr.Wrap(chkerr.ErrNXDomain, cause)},
+			},
+			chkerr.ExitNXDomain,
+		},
+		{
+			"real failure takes precedence over nxdomain",
+			[]Result{
+				{Name: "a", Error: chkerr.Wrap(chkerr.ErrNXDomain, cause)},
+				{Name: "b", Error: chkerr.Wrap(chkerr.ErrNetwork, cause)},
+			},
+			chkerr.ExitNetwork,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := aggregateExitCode(tc.results); got != tc.want {
+				t.Errorf("aggregateExitCode(%v) = %d, want %d", tc.results, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Package cache provides a small in-memory LRU cache with optional
+// on-disk persistence, used to avoid repeating PTR and IP-info lookups
+// for IPs that recur across a batch run (many domains often resolve to
+// the same CDN addresses).
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// entry is one cached value, together with the key it was stored under
+// (kept alongside the value so eviction can remove it from the lookup
+// map) and its expiration time.
+type entry struct {
+	Key     string          `json:"key"`
+	Value   json.RawMessage `json:"value"`
+	Expires time.Time       `json:"expires"`
+}
+
+// Cache is an in-memory LRU cache keyed by string, with each entry
+// expiring at its own deadline rather than a cache-wide TTL. When built
+// with a non-empty path, Load and Save persist it as a single JSON file.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	path     string
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// New builds a Cache holding at most capacity entries. path, if
+// non-empty, is where Load and Save read and write the cache.
+func New(capacity int, path string) *Cache {
+	return &Cache{
+		capacity: capacity,
+		path:     path,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get looks up key and, if present and unexpired, decodes its value
+// into out (which must be a pointer, as for json.Unmarshal).
+func (c *Cache) Get(key string, out interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	e := el.Value.(*entry)
+	if time.Now().After(e.Expires) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return false
+	}
+	c.ll.MoveToFront(el)
+	return json.Unmarshal(e.Value, out) == nil
+}
+
+// Set stores value under key, expiring it after ttl. A zero or negative
+// ttl is a no-op, since the entry would already be expired.
+func (c *Cache) Set(key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	expires := time.Now().Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.Value, e.Expires = raw, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&entry{Key: key, Value: raw, Expires: expires})
+	c.items[key] = el
+	if c.ll.Len() > c.capacity {
+		c.evictOldest()
+	}
+}
+
+// evictOldest drops the least recently used entry. The caller must hold
+// c.mu.
+func (c *Cache) evictOldest() {
+	el := c.ll.Back()
+	if el == nil {
+		return
+	}
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry).Key)
+}
+
+// Load populates the cache from its persisted JSON file, if one exists.
+// A missing file is not an error.
+func (c *Cache) Load() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("cache: read %s: %w", c.path, err)
+	}
+
+	var entries []entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("cache: decode %s: %w", c.path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	// Stored oldest-to-newest by Save; push in that order so the most
+	// recently used entry ends up at the front.
+	for i := range entries {
+		e := entries[i]
+		if now.After(e.Expires) {
+			continue
+		}
+		el := c.ll.PushFront(&e)
+		c.items[e.Key] = el
+	}
+	return nil
+}
+
+// Save persists the cache to its configured path as a single JSON file,
+// creating parent directories as needed. It is a no-op when no path was
+// configured.
+func (c *Cache) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	entries := make([]entry, 0, c.ll.Len())
+	for el := c.ll.Back(); el != nil; el = el.Prev() {
+		entries = append(entries, *el.Value.(*entry))
+	}
+	c.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("cache: create cache dir: %w", err)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("cache: encode: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("cache: write %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// Clear empties the cache in memory and removes its persisted file, if
+// any.
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.mu.Unlock()
+
+	if c.path == "" {
+		return nil
+	}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cache: remove %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// DefaultDir returns the base directory chk stores its caches under:
+// $XDG_CACHE_HOME/chk, or ~/.cache/chk when XDG_CACHE_HOME is unset.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "chk"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cache: resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".cache", "chk"), nil
+}
@@ -0,0 +1,108 @@
+// Package chkerr defines the failure categories chk distinguishes
+// between when a lookup fails, so callers can tell "the name doesn't
+// exist" apart from "the network is down" without parsing error text,
+// and so main can map a failure to a distinct process exit code.
+package chkerr
+
+import (
+	"errors"
+	"net"
+)
+
+// Sentinel errors identifying each failure category. Wrap a cause under
+// one of these with Wrap, and test for it with errors.Is.
+var (
+	// ErrNXDomain means the queried name does not exist.
+	ErrNXDomain = errors.New("name does not exist")
+	// ErrTimeout means the operation did not complete before its
+	// deadline.
+	ErrTimeout = errors.New("operation timed out")
+	// ErrNetwork means the request could not be completed: a dial
+	// failure, a non-success response, or similar.
+	ErrNetwork = errors.New("network error")
+	// ErrRateLimited means the server rejected the request for making
+	// too many of them.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrAPIAuth means the server rejected the request's credentials.
+	ErrAPIAuth = errors.New("api authentication failed")
+)
+
+// Error pairs a cause with the category it was classified under.
+type Error struct {
+	Category error
+	Cause    error
+}
+
+// Wrap reports cause as belonging to category. cause may be nil, in
+// which case Error returns just category's message.
+func Wrap(category, cause error) *Error {
+	return &Error{Category: category, Cause: cause}
+}
+
+func (e *Error) Error() string {
+	if e.Cause == nil {
+		return e.Category.Error()
+	}
+	return e.Category.Error() + ": " + e.Cause.Error()
+}
+
+// Unwrap exposes both e.Category, so errors.Is(err, chkerr.ErrTimeout)
+// works, and e.Cause, so the original error underneath it can still be
+// inspected.
+func (e *Error) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Category}
+	}
+	return []error{e.Category, e.Cause}
+}
+
+// Exit codes for each category, returned by ExitCode.
+const (
+	ExitOK          = 0
+	ExitGeneral     = 1
+	ExitNXDomain    = 2
+	ExitTimeout     = 3
+	ExitNetwork     = 4
+	ExitRateLimited = 5
+	ExitAPIAuth     = 6
+)
+
+// FromDNSError classifies err, as returned by net.LookupIP,
+// net.LookupAddr and similar stdlib resolvers, into a category. err is
+// returned unchanged if it is not a *net.DNSError.
+func FromDNSError(err error) error {
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) {
+		return err
+	}
+	switch {
+	case dnsErr.IsNotFound:
+		return Wrap(ErrNXDomain, err)
+	case dnsErr.IsTimeout:
+		return Wrap(ErrTimeout, err)
+	default:
+		return Wrap(ErrNetwork, err)
+	}
+}
+
+// ExitCode maps err to the process exit code chk reports for it: 0 for
+// a nil error, one of the category codes above for an error wrapped
+// with Wrap, or ExitGeneral for anything uncategorized.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrNXDomain):
+		return ExitNXDomain
+	case errors.Is(err, ErrTimeout):
+		return ExitTimeout
+	case errors.Is(err, ErrNetwork):
+		return ExitNetwork
+	case errors.Is(err, ErrRateLimited):
+		return ExitRateLimited
+	case errors.Is(err, ErrAPIAuth):
+		return ExitAPIAuth
+	default:
+		return ExitGeneral
+	}
+}
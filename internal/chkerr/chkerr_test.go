@@ -0,0 +1,58 @@
+package chkerr
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	cause := errors.New("boom")
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"nxdomain", Wrap(ErrNXDomain, cause), ExitNXDomain},
+		{"timeout", Wrap(ErrTimeout, cause), ExitTimeout},
+		{"network", Wrap(ErrNetwork, cause), ExitNetwork},
+		{"rate limited", Wrap(ErrRateLimited, cause), ExitRateLimited},
+		{"api auth", Wrap(ErrAPIAuth, cause), ExitAPIAuth},
+		{"uncategorized", cause, ExitGeneral},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ExitCode(tc.err); got != tc.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFromDNSError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"not found", &net.DNSError{Err: "no such host", IsNotFound: true}, ErrNXDomain},
+		{"timeout", &net.DNSError{Err: "i/o timeout", IsTimeout: true}, ErrTimeout},
+		{"other dns error", &net.DNSError{Err: "server misbehaving"}, ErrNetwork},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := FromDNSError(tc.err)
+			if !errors.Is(got, tc.want) {
+				t.Errorf("FromDNSError(%v) = %v, want category %v", tc.err, got, tc.want)
+			}
+		})
+	}
+
+	t.Run("non-DNS error passthrough", func(t *testing.T) {
+		other := errors.New("not a dns error")
+		if got := FromDNSError(other); got != other {
+			t.Errorf("FromDNSError(%v) = %v, want unchanged", other, got)
+		}
+	})
+}
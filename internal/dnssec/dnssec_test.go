@@ -0,0 +1,127 @@
+package dnssec
+
+import (
+	"crypto"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// signedRRSet builds a synthetic DNSKEY/RRSIG pair that signs an A
+// record for zone, with the signature's validity window offset by age
+// (negative to backdate Inception/Expiration into the past).
+func signedRRSet(t *testing.T, zone string, age time.Duration) ([]dns.RR, []*dns.RRSIG, []*dns.DNSKEY) {
+	t.Helper()
+
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     257,
+		Protocol:  3,
+		Algorithm: dns.RSASHA256,
+	}
+	priv, err := key.Generate(1024)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	a := &dns.A{
+		Hdr: dns.RR_Header{Name: zone, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
+		A:   net.ParseIP("192.0.2.1"),
+	}
+	rrset := []dns.RR{a}
+
+	sig := &dns.RRSIG{
+		Hdr:         dns.RR_Header{Name: zone, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: 3600},
+		TypeCovered: dns.TypeA,
+		Algorithm:   dns.RSASHA256,
+		Labels:      uint8(dns.CountLabel(zone)),
+		OrigTtl:     3600,
+		Expiration:  uint32(time.Now().Add(age + time.Hour).Unix()),
+		Inception:   uint32(time.Now().Add(age - time.Hour).Unix()),
+		KeyTag:      key.KeyTag(),
+		SignerName:  zone,
+	}
+	if err := sig.Sign(priv.(crypto.Signer), rrset); err != nil {
+		t.Fatalf("sign rrset: %v", err)
+	}
+
+	return rrset, []*dns.RRSIG{sig}, []*dns.DNSKEY{key}
+}
+
+func TestVerifiedBy(t *testing.T) {
+	t.Run("valid signature verifies", func(t *testing.T) {
+		rrset, sigs, keys := signedRRSet(t, "example.com.", 0)
+		if !verifiedBy(rrset, sigs, keys) {
+			t.Error("verifiedBy() = false, want true")
+		}
+	})
+
+	t.Run("expired signature is rejected", func(t *testing.T) {
+		rrset, sigs, keys := signedRRSet(t, "example.com.", -2*time.Hour)
+		if verifiedBy(rrset, sigs, keys) {
+			t.Error("verifiedBy() = true for an expired signature, want false")
+		}
+	})
+
+	t.Run("not-yet-valid signature is rejected", func(t *testing.T) {
+		rrset, sigs, keys := signedRRSet(t, "example.com.", 2*time.Hour)
+		if verifiedBy(rrset, sigs, keys) {
+			t.Error("verifiedBy() = true for a not-yet-valid signature, want false")
+		}
+	})
+
+	t.Run("wrong key fails", func(t *testing.T) {
+		rrset, sigs, _ := signedRRSet(t, "example.com.", 0)
+		_, _, otherKeys := signedRRSet(t, "example.com.", 0)
+		if verifiedBy(rrset, sigs, otherKeys) {
+			t.Error("verifiedBy() = true against a non-matching key, want false")
+		}
+	})
+}
+
+func TestAnyDSMatchesKey(t *testing.T) {
+	_, _, keys := signedRRSet(t, "example.com.", 0)
+	key := keys[0]
+
+	t.Run("matching DS", func(t *testing.T) {
+		ds := key.ToDS(dns.SHA256)
+		if !anyDSMatchesKey([]dns.RR{ds}, keys) {
+			t.Error("anyDSMatchesKey() = false for a matching DS, want true")
+		}
+	})
+
+	t.Run("non-matching DS", func(t *testing.T) {
+		ds := key.ToDS(dns.SHA256)
+		ds.KeyTag = ds.KeyTag + 1
+		if anyDSMatchesKey([]dns.RR{ds}, keys) {
+			t.Error("anyDSMatchesKey() = true for a non-matching DS, want false")
+		}
+	})
+
+	t.Run("no DS records", func(t *testing.T) {
+		if anyDSMatchesKey(nil, keys) {
+			t.Error("anyDSMatchesKey() = true with no DS records, want false")
+		}
+	})
+}
+
+func TestParentZone(t *testing.T) {
+	cases := []struct {
+		zone string
+		want string
+	}{
+		{"www.example.com.", "example.com."},
+		{"example.com.", "com."},
+		{"com.", "."},
+		{".", "."},
+	}
+	for _, tc := range cases {
+		t.Run(tc.zone, func(t *testing.T) {
+			if got := parentZone(tc.zone); got != tc.want {
+				t.Errorf("parentZone(%q) = %q, want %q", tc.zone, got, tc.want)
+			}
+		})
+	}
+}
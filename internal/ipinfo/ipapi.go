@@ -0,0 +1,71 @@
+package ipinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ipapiProvider queries the ip-api.com JSON API.
+type ipapiProvider struct {
+	client *http.Client
+}
+
+func newIPAPIProvider(cfg Config) *ipapiProvider {
+	return &ipapiProvider{client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+func (p *ipapiProvider) Lookup(ctx context.Context, ip string) (*Info, error) {
+	url := "http://ip-api.com/json/" + ip + "?fields=status,message,query,city,regionName,country,lat,lon,org,as,reverse"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, requestError("ip-api", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("ip-api", resp)
+	}
+
+	var raw struct {
+		Status     string  `json:"status"`
+		Message    string  `json:"message"`
+		Query      string  `json:"query"`
+		City       string  `json:"city"`
+		RegionName string  `json:"regionName"`
+		Country    string  `json:"country"`
+		Lat        float64 `json:"lat"`
+		Lon        float64 `json:"lon"`
+		Org        string  `json:"org"`
+		As         string  `json:"as"`
+		Reverse    string  `json:"reverse"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("ip-api: decode response: %w", err)
+	}
+	if raw.Status != "success" {
+		return nil, fmt.Errorf("ip-api: %s", raw.Message)
+	}
+
+	// The "as" field is formatted as "AS15169 Google LLC".
+	asn, asName := splitOrg(raw.As)
+	return &Info{
+		IP:       raw.Query,
+		Hostname: raw.Reverse,
+		City:     raw.City,
+		Region:   raw.RegionName,
+		Country:  raw.Country,
+		Loc:      strconv.FormatFloat(raw.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(raw.Lon, 'f', -1, 64),
+		Org:      raw.Org,
+		ASN:      asn,
+		ASName:   asName,
+	}, nil
+}
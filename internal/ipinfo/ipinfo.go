@@ -0,0 +1,110 @@
+// Package ipinfo provides pluggable IP-information lookup backends for chk.
+//
+// By default chk queries the ipinfo.io HTTP API. Callers can instead select
+// ip-api.com or RIPEstat, or opt out of network calls entirely with the
+// maxmind provider, which resolves city and ASN data from local MaxMind
+// GeoLite2 .mmdb files.
+package ipinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kofany/chk/internal/chkerr"
+)
+
+// Name identifies an IP-info provider.
+type Name string
+
+// Supported providers.
+const (
+	ProviderIPInfo   Name = "ipinfo"
+	ProviderIPAPI    Name = "ipapi"
+	ProviderRIPEstat Name = "ripestat"
+	ProviderMaxMind  Name = "maxmind"
+)
+
+// Info is the information chk reports for a single IP address, normalized
+// across providers.
+type Info struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname,omitempty"`
+	City     string `json:"city,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Loc      string `json:"loc,omitempty"`
+	Org      string `json:"org,omitempty"`
+	ASN      string `json:"asn,omitempty"`
+	ASName   string `json:"as_name,omitempty"`
+	Network  string `json:"network,omitempty"`
+}
+
+// Provider looks up Info for an IP address.
+type Provider interface {
+	// Lookup returns IP-info for ip.
+	Lookup(ctx context.Context, ip string) (*Info, error)
+}
+
+// Config selects and configures a Provider.
+type Config struct {
+	Provider Name
+	// APIToken authenticates requests to ipinfo.io. Unused by the other
+	// providers.
+	APIToken string
+	// GeoIPDB is the path to a GeoLite2-City .mmdb file. The maxmind
+	// provider requires at least one of GeoIPDB and ASNDB.
+	GeoIPDB string
+	// ASNDB is the path to a GeoLite2-ASN .mmdb file. The maxmind
+	// provider requires at least one of GeoIPDB and ASNDB.
+	ASNDB   string
+	Timeout time.Duration
+}
+
+// New builds the Provider for cfg.Provider.
+func New(cfg Config) (Provider, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	switch cfg.Provider {
+	case "", ProviderIPInfo:
+		return newIPInfoProvider(cfg), nil
+	case ProviderIPAPI:
+		return newIPAPIProvider(cfg), nil
+	case ProviderRIPEstat:
+		return newRIPEstatProvider(cfg), nil
+	case ProviderMaxMind:
+		return newMaxMindProvider(cfg)
+	default:
+		return nil, fmt.Errorf("ipinfo: unknown provider %q", cfg.Provider)
+	}
+}
+
+// requestError classifies a client.Do failure from an HTTP-backed
+// provider: a timeout, or a generic network error.
+func requestError(provider string, err error) error {
+	cause := fmt.Errorf("%s: request: %w", provider, err)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return chkerr.Wrap(chkerr.ErrTimeout, cause)
+	}
+	return chkerr.Wrap(chkerr.ErrNetwork, cause)
+}
+
+// statusError classifies a non-2xx response from an HTTP-backed
+// provider: an authentication failure, a rate limit, or a generic
+// network error.
+func statusError(provider string, resp *http.Response) error {
+	cause := fmt.Errorf("%s: unexpected status %s", provider, resp.Status)
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return chkerr.Wrap(chkerr.ErrAPIAuth, cause)
+	case http.StatusTooManyRequests:
+		return chkerr.Wrap(chkerr.ErrRateLimited, cause)
+	default:
+		return chkerr.Wrap(chkerr.ErrNetwork, cause)
+	}
+}
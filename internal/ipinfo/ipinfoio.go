@@ -0,0 +1,85 @@
+package ipinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ipinfoProvider queries the ipinfo.io JSON API.
+type ipinfoProvider struct {
+	token  string
+	client *http.Client
+}
+
+func newIPInfoProvider(cfg Config) *ipinfoProvider {
+	return &ipinfoProvider{
+		token:  cfg.APIToken,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (p *ipinfoProvider) Lookup(ctx context.Context, ip string) (*Info, error) {
+	url := "https://ipinfo.io/" + ip + "/json"
+	if p.token != "" {
+		url += "?token=" + p.token
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, requestError("ipinfo", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("ipinfo", resp)
+	}
+
+	var raw struct {
+		IP       string `json:"ip"`
+		Hostname string `json:"hostname"`
+		City     string `json:"city"`
+		Region   string `json:"region"`
+		Country  string `json:"country"`
+		Loc      string `json:"loc"`
+		Org      string `json:"org"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("ipinfo: decode response: %w", err)
+	}
+
+	// The "org" field doubles as ASN and AS-name, formatted as
+	// "AS<number> <name>".
+	asn, asName := splitOrg(raw.Org)
+	return &Info{
+		IP:       raw.IP,
+		Hostname: raw.Hostname,
+		City:     raw.City,
+		Region:   raw.Region,
+		Country:  raw.Country,
+		Loc:      raw.Loc,
+		Org:      raw.Org,
+		ASN:      asn,
+		ASName:   asName,
+	}, nil
+}
+
+// splitOrg splits an ipinfo.io "org" field of the form "AS15169 Google LLC"
+// into its ASN and name.
+func splitOrg(org string) (asn, name string) {
+	if !strings.HasPrefix(org, "AS") {
+		return "", org
+	}
+	i := strings.IndexByte(org, ' ')
+	if i < 0 {
+		return org, ""
+	}
+	return org[:i], strings.TrimSpace(org[i+1:])
+}
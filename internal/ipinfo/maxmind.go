@@ -0,0 +1,109 @@
+package ipinfo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// maxmindProvider resolves city and ASN data from local MaxMind
+// GeoLite2-City and GeoLite2-ASN .mmdb files. It makes no network calls.
+type maxmindProvider struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+func newMaxMindProvider(cfg Config) (*maxmindProvider, error) {
+	if cfg.GeoIPDB == "" && cfg.ASNDB == "" {
+		return nil, fmt.Errorf("ipinfo: --geoip-db or --asn-db is required for maxmind")
+	}
+
+	p := &maxmindProvider{}
+	if cfg.GeoIPDB != "" {
+		city, err := maxminddb.Open(cfg.GeoIPDB)
+		if err != nil {
+			return nil, fmt.Errorf("ipinfo: open geoip-db: %w", err)
+		}
+		p.city = city
+	}
+	if cfg.ASNDB != "" {
+		asn, err := maxminddb.Open(cfg.ASNDB)
+		if err != nil {
+			return nil, fmt.Errorf("ipinfo: open asn-db: %w", err)
+		}
+		p.asn = asn
+	}
+	return p, nil
+}
+
+// cityRecord mirrors the fields chk uses from a GeoLite2-City record.
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	Country struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+// asnRecord mirrors the fields chk uses from a GeoLite2-ASN record.
+type asnRecord struct {
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+func (p *maxmindProvider) Lookup(_ context.Context, ip string) (*Info, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("ipinfo: invalid IP %q", ip)
+	}
+
+	info := &Info{IP: ip}
+
+	if p.city != nil {
+		var rec cityRecord
+		network, _, err := p.city.LookupNetwork(addr, &rec)
+		if err != nil {
+			return nil, fmt.Errorf("ipinfo: geoip-db lookup: %w", err)
+		}
+		info.City = rec.City.Names["en"]
+		info.Country = rec.Country.Names["en"]
+		if len(rec.Subdivisions) > 0 {
+			info.Region = rec.Subdivisions[0].Names["en"]
+		}
+		if rec.Location.Latitude != 0 || rec.Location.Longitude != 0 {
+			info.Loc = strconv.FormatFloat(rec.Location.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(rec.Location.Longitude, 'f', -1, 64)
+		}
+		if network != nil {
+			info.Network = network.String()
+		}
+	}
+
+	if p.asn != nil {
+		var rec asnRecord
+		network, _, err := p.asn.LookupNetwork(addr, &rec)
+		if err != nil {
+			return nil, fmt.Errorf("ipinfo: asn-db lookup: %w", err)
+		}
+		if rec.AutonomousSystemNumber != 0 {
+			info.ASN = "AS" + strconv.FormatUint(uint64(rec.AutonomousSystemNumber), 10)
+			info.ASName = rec.AutonomousSystemOrganization
+			info.Org = info.ASName
+		}
+		if info.Network == "" && network != nil {
+			info.Network = network.String()
+		}
+	}
+
+	return info, nil
+}
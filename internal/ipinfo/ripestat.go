@@ -0,0 +1,101 @@
+package ipinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ripestatProvider queries the RIPEstat Data API, which requires no API
+// key and covers allocations outside the RIPE NCC region as well.
+type ripestatProvider struct {
+	client *http.Client
+}
+
+func newRIPEstatProvider(cfg Config) *ripestatProvider {
+	return &ripestatProvider{client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// ripestatNetworkInfo decodes the network-info endpoint, which returns the
+// prefix and originating ASNs for an address.
+type ripestatNetworkInfo struct {
+	Data struct {
+		Prefix string   `json:"prefix"`
+		ASNs   []string `json:"asns"`
+	} `json:"data"`
+}
+
+// ripestatASOverview decodes the as-overview endpoint, which returns the
+// holder name for an ASN.
+type ripestatASOverview struct {
+	Data struct {
+		Holder string `json:"holder"`
+	} `json:"data"`
+}
+
+// ripestatGeoloc decodes the geolocation endpoint.
+type ripestatGeoloc struct {
+	Data struct {
+		LocatedResources []struct {
+			Locations []struct {
+				Country   string  `json:"country"`
+				City      string  `json:"city"`
+				Latitude  float64 `json:"latitude"`
+				Longitude float64 `json:"longitude"`
+			} `json:"locations"`
+		} `json:"located_resources"`
+	} `json:"data"`
+}
+
+func (p *ripestatProvider) get(ctx context.Context, endpoint, resource string, out interface{}) error {
+	url := fmt.Sprintf("https://stat.ripe.net/data/%s/data.json?resource=%s", endpoint, resource)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return requestError("ripestat", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError("ripestat", resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ripestat: decode %s response: %w", endpoint, err)
+	}
+	return nil
+}
+
+func (p *ripestatProvider) Lookup(ctx context.Context, ip string) (*Info, error) {
+	var net ripestatNetworkInfo
+	if err := p.get(ctx, "network-info", ip, &net); err != nil {
+		return nil, err
+	}
+
+	info := &Info{IP: ip, Network: net.Data.Prefix}
+	if len(net.Data.ASNs) > 0 {
+		info.ASN = "AS" + net.Data.ASNs[0]
+
+		var asOverview ripestatASOverview
+		if err := p.get(ctx, "as-overview", net.Data.ASNs[0], &asOverview); err == nil {
+			info.ASName = strings.TrimSpace(asOverview.Data.Holder)
+		}
+	}
+
+	var geo ripestatGeoloc
+	if err := p.get(ctx, "geoloc", ip, &geo); err == nil && len(geo.Data.LocatedResources) > 0 {
+		locations := geo.Data.LocatedResources[0].Locations
+		if len(locations) > 0 {
+			loc := locations[0]
+			info.City = loc.City
+			info.Country = loc.Country
+			info.Loc = strconv.FormatFloat(loc.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(loc.Longitude, 'f', -1, 64)
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,140 @@
+// Package mdns implements a minimal multicast DNS (RFC 6762) client,
+// used by chk to discover .local hosts and services on the local
+// network instead of querying a unicast nameserver.
+package mdns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// ipv4Group and ipv6Group are the mDNS multicast groups defined by
+// RFC 6762 §3.
+const (
+	ipv4Group = "224.0.0.251:5353"
+	ipv6Group = "[ff02::fb]:5353"
+)
+
+// Config configures a Client.
+type Config struct {
+	// Timeout is how long Query listens for responses after sending its
+	// query. Unlike unicast DNS, mDNS has no single authoritative
+	// answer: every responder on the network may reply.
+	Timeout time.Duration
+}
+
+// Client issues mDNS queries over both the IPv4 and IPv6 multicast
+// groups and collects whatever responses arrive within its timeout.
+type Client struct {
+	timeout time.Duration
+}
+
+// New builds a Client from cfg, defaulting Timeout to one second.
+func New(cfg Config) *Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	return &Client{timeout: timeout}
+}
+
+// Query sends a one-shot mDNS query for name/qtype over both address
+// families and returns every distinct RR received before the timeout
+// elapses, including records any responder attached as additional data
+// (e.g. the SRV/A/AAAA records a PTR-based service query pulls in).
+func (c *Client) Query(ctx context.Context, name string, qtype uint16) ([]dns.RR, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	m.RecursionDesired = false
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("mdns: pack query: %w", err)
+	}
+
+	deadline := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+
+	var mu sync.Mutex
+	var rrs []dns.RR
+	var wg sync.WaitGroup
+	for _, group := range []struct{ network, addr string }{
+		{"udp4", ipv4Group},
+		{"udp6", ipv6Group},
+	} {
+		wg.Add(1)
+		go func(network, addr string) {
+			defer wg.Done()
+			got, err := queryGroup(network, addr, packed, deadline)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			rrs = append(rrs, got...)
+			mu.Unlock()
+		}(group.network, group.addr)
+	}
+	wg.Wait()
+
+	return dedupe(rrs), nil
+}
+
+// queryGroup sends packed to the multicast group at addr and collects
+// every response received over that socket before deadline.
+func queryGroup(network, addr string, packed []byte, deadline time.Time) ([]dns.RR, error) {
+	groupAddr, err := net.ResolveUDPAddr(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("mdns: resolve %s: %w", addr, err)
+	}
+
+	conn, err := net.ListenMulticastUDP(network, nil, groupAddr)
+	if err != nil {
+		return nil, fmt.Errorf("mdns: join %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(packed, groupAddr); err != nil {
+		return nil, fmt.Errorf("mdns: send query: %w", err)
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("mdns: set read deadline: %w", err)
+	}
+
+	var rrs []dns.RR
+	buf := make([]byte, 65535)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break // read deadline reached
+		}
+		in := new(dns.Msg)
+		if err := in.Unpack(buf[:n]); err != nil {
+			continue
+		}
+		rrs = append(rrs, in.Answer...)
+		rrs = append(rrs, in.Extra...)
+	}
+	return rrs, nil
+}
+
+// dedupe drops repeated RRs, since mDNS responders commonly announce
+// the same records more than once.
+func dedupe(rrs []dns.RR) []dns.RR {
+	seen := make(map[string]bool, len(rrs))
+	out := make([]dns.RR, 0, len(rrs))
+	for _, rr := range rrs {
+		key := rr.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, rr)
+	}
+	return out
+}
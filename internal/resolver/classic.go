@@ -0,0 +1,61 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// classicResolver issues plain UDP or TCP DNS queries via github.com/miekg/dns.
+type classicResolver struct {
+	client *dns.Client
+	server string
+	dnssec bool
+}
+
+func newClassicResolver(transport string, cfg Config) (Resolver, error) {
+	server := cfg.Server
+	if server == "" {
+		resolved, err := systemServer()
+		if err != nil {
+			return nil, fmt.Errorf("resolver: determine system nameserver: %w", err)
+		}
+		server = resolved
+	} else {
+		server = withDefaultPort(server, "53")
+	}
+	return &classicResolver{
+		client: &dns.Client{Net: transport, Timeout: cfg.Timeout},
+		server: server,
+		dnssec: cfg.DNSSEC,
+	}, nil
+}
+
+// systemServer returns the first nameserver from /etc/resolv.conf, the
+// same source the operating system's own resolver reads, rather than
+// assuming a local stub resolver is listening on 127.0.0.1:53.
+func systemServer() (string, error) {
+	conf, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil {
+		return "", err
+	}
+	if len(conf.Servers) == 0 {
+		return "", fmt.Errorf("no nameservers found in /etc/resolv.conf")
+	}
+	return net.JoinHostPort(conf.Servers[0], conf.Port), nil
+}
+
+func (r *classicResolver) Query(ctx context.Context, name string, qtype uint16) ([]dns.RR, error) {
+	m := newQuery(name, qtype, r.dnssec)
+
+	in, _, err := r.client.ExchangeContext(ctx, m, r.server)
+	if err != nil {
+		return nil, dialError(fmt.Errorf("classic: exchange %s: %w", r.server, err))
+	}
+	if err := rcodeError(in); err != nil {
+		return nil, err
+	}
+	return in.Answer, nil
+}
@@ -0,0 +1,87 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/miekg/dns"
+
+	"github.com/kofany/chk/internal/chkerr"
+)
+
+// dohResolver issues DNS-over-HTTPS queries using the RFC 8484 wire format
+// (application/dns-message), not the JSON API.
+type dohResolver struct {
+	endpoint string
+	client   *http.Client
+	dnssec   bool
+}
+
+func newDoHResolver(cfg Config) (Resolver, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("resolver: --dns-server is required for doh")
+	}
+	return &dohResolver{
+		endpoint: cfg.Server,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		dnssec:   cfg.DNSSEC,
+	}, nil
+}
+
+func (r *dohResolver) Query(ctx context.Context, name string, qtype uint16) ([]dns.RR, error) {
+	m := newQuery(name, qtype, r.dnssec)
+	m.Id = 0 // RFC 8484 recommends 0 so responses remain cacheable
+
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, dialError(fmt.Errorf("doh: request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, dialError(fmt.Errorf("doh: read response: %w", err))
+	}
+
+	in := new(dns.Msg)
+	if err := in.Unpack(body); err != nil {
+		return nil, fmt.Errorf("doh: unpack response: %w", err)
+	}
+	if err := rcodeError(in); err != nil {
+		return nil, err
+	}
+	return in.Answer, nil
+}
+
+// statusError classifies a non-200 response from the DoH endpoint: an
+// authentication failure, a rate limit, or a generic network error.
+func statusError(resp *http.Response) error {
+	cause := fmt.Errorf("doh: unexpected status %s", resp.Status)
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return chkerr.Wrap(chkerr.ErrAPIAuth, cause)
+	case http.StatusTooManyRequests:
+		return chkerr.Wrap(chkerr.ErrRateLimited, cause)
+	default:
+		return chkerr.Wrap(chkerr.ErrNetwork, cause)
+	}
+}
@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/quic-go/quic-go"
+)
+
+// doqALPN is the ALPN token for DNS-over-QUIC (RFC 9250 §7.1).
+const doqALPN = "doq"
+
+// doqResolver issues DNS-over-QUIC queries (RFC 9250): one query per
+// bidirectional stream, length-prefixed like DoT.
+type doqResolver struct {
+	server  string
+	timeout time.Duration
+	dnssec  bool
+}
+
+func newDoQResolver(cfg Config) (Resolver, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("resolver: --dns-server is required for doq")
+	}
+	return &doqResolver{
+		server:  withDefaultPort(cfg.Server, "853"),
+		timeout: cfg.Timeout,
+		dnssec:  cfg.DNSSEC,
+	}, nil
+}
+
+func (r *doqResolver) Query(ctx context.Context, name string, qtype uint16) ([]dns.RR, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	conn, err := quic.DialAddr(ctx, r.server, &tls.Config{NextProtos: []string{doqALPN}}, nil)
+	if err != nil {
+		return nil, dialError(fmt.Errorf("doq: dial %s: %w", r.server, err))
+	}
+	defer conn.CloseWithError(0, "")
+
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, dialError(fmt.Errorf("doq: open stream: %w", err))
+	}
+	defer stream.Close()
+
+	m := newQuery(name, qtype, r.dnssec)
+	m.Id = 0 // RFC 9250 §4.2.1 mandates a zero message ID on the wire
+
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var lenBuf [2]byte
+	binary.BigEndian.PutUint16(lenBuf[:], uint16(len(packed)))
+	if _, err := stream.Write(append(lenBuf[:], packed...)); err != nil {
+		return nil, dialError(fmt.Errorf("doq: write: %w", err))
+	}
+	stream.Close() // half-close signals end of request, per RFC 9250 §4.2
+
+	if _, err := io.ReadFull(stream, lenBuf[:]); err != nil {
+		return nil, dialError(fmt.Errorf("doq: read length: %w", err))
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(lenBuf[:]))
+	if _, err := io.ReadFull(stream, resp); err != nil {
+		return nil, dialError(fmt.Errorf("doq: read response: %w", err))
+	}
+
+	in := new(dns.Msg)
+	if err := in.Unpack(resp); err != nil {
+		return nil, fmt.Errorf("doq: unpack response: %w", err)
+	}
+	if err := rcodeError(in); err != nil {
+		return nil, err
+	}
+	return in.Answer, nil
+}
@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// dotResolver issues DNS-over-TLS queries (RFC 7858): a 2-byte big-endian
+// length prefix followed by the wire-format message, over a TLS connection.
+type dotResolver struct {
+	server  string
+	tlsConf *tls.Config
+	timeout time.Duration
+	dnssec  bool
+}
+
+func newDoTResolver(cfg Config) (Resolver, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("resolver: --dns-server is required for dot")
+	}
+	server := withDefaultPort(cfg.Server, "853")
+	host, _, err := net.SplitHostPort(server)
+	if err != nil {
+		host = cfg.Server
+	}
+	return &dotResolver{
+		server:  server,
+		tlsConf: &tls.Config{ServerName: host},
+		timeout: cfg.Timeout,
+		dnssec:  cfg.DNSSEC,
+	}, nil
+}
+
+func (r *dotResolver) Query(ctx context.Context, name string, qtype uint16) ([]dns.RR, error) {
+	m := newQuery(name, qtype, r.dnssec)
+
+	dialer := &net.Dialer{Timeout: r.timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", r.server, r.tlsConf)
+	if err != nil {
+		return nil, dialError(fmt.Errorf("dot: dial %s: %w", r.server, err))
+	}
+	defer conn.Close()
+
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	} else {
+		conn.SetDeadline(time.Now().Add(r.timeout))
+	}
+
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var lenBuf [2]byte
+	binary.BigEndian.PutUint16(lenBuf[:], uint16(len(packed)))
+	if _, err := conn.Write(append(lenBuf[:], packed...)); err != nil {
+		return nil, dialError(fmt.Errorf("dot: write: %w", err))
+	}
+
+	if _, err := io.ReadFull(conn, lenBuf[:]); err != nil {
+		return nil, dialError(fmt.Errorf("dot: read length: %w", err))
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(lenBuf[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, dialError(fmt.Errorf("dot: read response: %w", err))
+	}
+
+	in := new(dns.Msg)
+	if err := in.Unpack(resp); err != nil {
+		return nil, fmt.Errorf("dot: unpack response: %w", err)
+	}
+	if err := rcodeError(in); err != nil {
+		return nil, err
+	}
+	return in.Answer, nil
+}
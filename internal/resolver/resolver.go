@@ -0,0 +1,127 @@
+// Package resolver provides pluggable DNS resolver backends for chk.
+//
+// By default chk defers to the operating system's resolver. In
+// environments where the system resolver is unreliable, censored, or
+// simply absent, callers can select an explicit Resolver instead: classic
+// UDP/TCP DNS, DNS-over-TLS (RFC 7858), DNS-over-HTTPS (RFC 8484),
+// DNS-over-QUIC (RFC 9250), or DNSCrypt.
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/kofany/chk/internal/chkerr"
+)
+
+// Protocol identifies a DNS transport.
+type Protocol string
+
+// Supported transports.
+const (
+	ProtocolUDP      Protocol = "udp"
+	ProtocolTCP      Protocol = "tcp"
+	ProtocolDoT      Protocol = "dot"
+	ProtocolDoH      Protocol = "doh"
+	ProtocolDoQ      Protocol = "doq"
+	ProtocolDNSCrypt Protocol = "dnscrypt"
+)
+
+// Resolver performs DNS queries against a configured nameserver over a
+// specific transport.
+type Resolver interface {
+	// Query resolves name for the given record type (e.g. dns.TypeA,
+	// dns.TypePTR) and returns the raw answer RRs.
+	Query(ctx context.Context, name string, qtype uint16) ([]dns.RR, error)
+}
+
+// Config describes how to reach a nameserver.
+type Config struct {
+	// Server is the nameserver address. Its shape depends on Protocol:
+	// host:port for udp/tcp/dot, a full URL for doh, and an sdns://
+	// stamp for dnscrypt.
+	Server   string
+	Protocol Protocol
+	Timeout  time.Duration
+	// DNSSEC sets the EDNS0 DO bit on every query, asking the server to
+	// include RRSIG records alongside answers.
+	DNSSEC bool
+}
+
+// New builds the Resolver for cfg.Protocol, applying sensible defaults
+// (port 53 for udp/tcp, port 853 for dot/doq) when Server omits a port.
+func New(cfg Config) (Resolver, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	switch cfg.Protocol {
+	case "", ProtocolUDP:
+		return newClassicResolver("udp", cfg)
+	case ProtocolTCP:
+		return newClassicResolver("tcp", cfg)
+	case ProtocolDoT:
+		return newDoTResolver(cfg)
+	case ProtocolDoH:
+		return newDoHResolver(cfg)
+	case ProtocolDoQ:
+		return newDoQResolver(cfg)
+	case ProtocolDNSCrypt:
+		return newDNSCryptResolver(cfg)
+	default:
+		return nil, fmt.Errorf("resolver: unknown protocol %q", cfg.Protocol)
+	}
+}
+
+// newQuery builds a recursive query for name/qtype, setting the EDNS0 DO
+// (DNSSEC OK) bit when dnssec is true so the answer includes RRSIGs.
+func newQuery(name string, qtype uint16, dnssec bool) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	m.RecursionDesired = true
+	if dnssec {
+		m.SetEdns0(4096, true)
+	}
+	return m
+}
+
+// withDefaultPort appends port to server when server has none of its own.
+func withDefaultPort(server, port string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(server, port)
+}
+
+// rcodeError turns a non-success Rcode into an error, classifying a
+// name-error response as chkerr.ErrNXDomain so callers can tell it apart
+// from a real failure.
+func rcodeError(in *dns.Msg) error {
+	if in.Rcode == dns.RcodeSuccess {
+		return nil
+	}
+	cause := fmt.Errorf("resolver: %s", dns.RcodeToString[in.Rcode])
+	if in.Rcode == dns.RcodeNameError {
+		return chkerr.Wrap(chkerr.ErrNXDomain, cause)
+	}
+	return chkerr.Wrap(chkerr.ErrNetwork, cause)
+}
+
+// dialError classifies cause, a dial/write/read/exchange failure against
+// a configured nameserver, as chkerr.ErrTimeout or chkerr.ErrNetwork so
+// callers get a meaningful exit code instead of the generic one. cause
+// should already describe what was being attempted (e.g. via %w).
+func dialError(cause error) error {
+	var netErr net.Error
+	if errors.As(cause, &netErr) && netErr.Timeout() {
+		return chkerr.Wrap(chkerr.ErrTimeout, cause)
+	}
+	if errors.Is(cause, context.DeadlineExceeded) {
+		return chkerr.Wrap(chkerr.ErrTimeout, cause)
+	}
+	return chkerr.Wrap(chkerr.ErrNetwork, cause)
+}